@@ -0,0 +1,276 @@
+// Package config loads the declarative service list the launcher starts,
+// replacing the hardcoded agent/UI paths with a services.yaml (or .json)
+// file under ~/.config/intervention/ or a path passed via --config.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"intervention/health"
+	"intervention/supervisor"
+)
+
+// BackoffConfig mirrors supervisor.Backoff with human-readable durations.
+type BackoffConfig struct {
+	Initial    string  `yaml:"initial" json:"initial"`
+	Max        string  `yaml:"max" json:"max"`
+	Multiplier float64 `yaml:"multiplier" json:"multiplier"`
+}
+
+// HealthCheckConfig declares one readiness probe kind: TCP, HTTP, or a shell
+// command exiting 0. If more than one is set, tcp is used over http, and
+// http over command.
+type HealthCheckConfig struct {
+	TCP              string `yaml:"tcp" json:"tcp"`
+	HTTP             string `yaml:"http" json:"http"`
+	HTTPExpectStatus int    `yaml:"http_expect_status" json:"http_expect_status"`
+	Command          string `yaml:"command" json:"command"`
+
+	Timeout  string `yaml:"timeout" json:"timeout"`
+	Interval string `yaml:"interval" json:"interval"`
+}
+
+// ServiceConfig is one entry in the services file.
+type ServiceConfig struct {
+	Name        string             `yaml:"name" json:"name"`
+	Command     string             `yaml:"command" json:"command"`
+	Args        []string           `yaml:"args" json:"args"`
+	Cwd         string             `yaml:"cwd" json:"cwd"`
+	Env         map[string]string  `yaml:"env" json:"env"`
+	DependsOn   []string           `yaml:"depends_on" json:"depends_on"`
+	HealthCheck *HealthCheckConfig `yaml:"health_check" json:"health_check"`
+
+	RestartPolicy string         `yaml:"restart_policy" json:"restart_policy"`
+	MaxRestarts   int            `yaml:"max_restarts" json:"max_restarts"`
+	Backoff       *BackoffConfig `yaml:"backoff" json:"backoff"`
+}
+
+// Config is the top-level shape of services.yaml / services.json.
+type Config struct {
+	Services []ServiceConfig `yaml:"services" json:"services"`
+
+	// StopPeersOnCleanExit mirrors supervisor.Supervisor.StopPeersOnCleanExit.
+	// Left nil, it defaults to true: a service that exits cleanly and is not
+	// restarted stops all the others. Set it to false to let unrelated
+	// services keep running in that case.
+	StopPeersOnCleanExit *bool `yaml:"stop_peers_on_clean_exit" json:"stop_peers_on_clean_exit"`
+}
+
+// DefaultPath returns ~/.config/intervention/services.yaml, preferring
+// services.json if that's the only one present.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("config: determining home directory: %w", err)
+	}
+	dir := filepath.Join(homeDir, ".config", "intervention")
+	yamlPath := filepath.Join(dir, "services.yaml")
+	if _, err := os.Stat(yamlPath); err == nil {
+		return yamlPath, nil
+	}
+	jsonPath := filepath.Join(dir, "services.json")
+	if _, err := os.Stat(jsonPath); err == nil {
+		return jsonPath, nil
+	}
+	return yamlPath, nil
+}
+
+// Load reads and parses the services file at path, choosing a YAML or JSON
+// decoder based on its extension.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	}
+
+	if len(cfg.Services) == 0 {
+		return nil, fmt.Errorf("config: %s declares no services", path)
+	}
+	return &cfg, nil
+}
+
+// ToServices converts the parsed config into supervisor.Services, ordered so
+// that every service appears after the services it depends on.
+func (c *Config) ToServices() ([]*supervisor.Service, error) {
+	ordered, err := topoSort(c.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*supervisor.Service, 0, len(ordered))
+	for _, sc := range ordered {
+		policy, err := parseRestartPolicy(sc.RestartPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("config: service %q: %w", sc.Name, err)
+		}
+
+		svc := &supervisor.Service{
+			Name:          sc.Name,
+			Command:       sc.Command,
+			Args:          sc.Args,
+			Dir:           sc.Cwd,
+			Stdout:        os.Stdout,
+			Stderr:        os.Stderr,
+			Stdin:         os.Stdin,
+			RestartPolicy: policy,
+			MaxRestarts:   sc.MaxRestarts,
+		}
+		if len(sc.Env) > 0 {
+			svc.Env = os.Environ()
+			for k, v := range sc.Env {
+				svc.Env = append(svc.Env, k+"="+v)
+			}
+		}
+		if sc.Backoff != nil {
+			b, err := parseBackoff(*sc.Backoff)
+			if err != nil {
+				return nil, fmt.Errorf("config: service %q: %w", sc.Name, err)
+			}
+			svc.Backoff = b
+		}
+		if sc.HealthCheck != nil {
+			probe, timeout, interval, err := parseHealthCheck(*sc.HealthCheck)
+			if err != nil {
+				return nil, fmt.Errorf("config: service %q: %w", sc.Name, err)
+			}
+			svc.ReadinessProbe = probe
+			svc.ReadinessTimeout = timeout
+			svc.ReadinessInterval = interval
+		}
+		out = append(out, svc)
+	}
+	return out, nil
+}
+
+func parseHealthCheck(hc HealthCheckConfig) (supervisor.ReadinessProbe, time.Duration, time.Duration, error) {
+	var probe supervisor.ReadinessProbe
+	switch {
+	case hc.TCP != "":
+		probe = health.TCPProbe{Address: hc.TCP}
+	case hc.HTTP != "":
+		probe = health.HTTPProbe{URL: hc.HTTP, ExpectStatus: hc.HTTPExpectStatus}
+	case hc.Command != "":
+		probe = health.CommandProbe{Command: hc.Command}
+	default:
+		return nil, 0, 0, fmt.Errorf("health_check must set one of tcp, http, or command")
+	}
+
+	timeout := 30 * time.Second
+	if hc.Timeout != "" {
+		d, err := time.ParseDuration(hc.Timeout)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("invalid health_check.timeout %q: %w", hc.Timeout, err)
+		}
+		timeout = d
+	}
+
+	var interval time.Duration
+	if hc.Interval != "" {
+		d, err := time.ParseDuration(hc.Interval)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("invalid health_check.interval %q: %w", hc.Interval, err)
+		}
+		interval = d
+	}
+
+	return probe, timeout, interval, nil
+}
+
+func parseRestartPolicy(s string) (supervisor.RestartPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "never":
+		return supervisor.Never, nil
+	case "on-failure", "onfailure":
+		return supervisor.OnFailure, nil
+	case "always":
+		return supervisor.Always, nil
+	default:
+		return supervisor.Never, fmt.Errorf("unknown restart_policy %q", s)
+	}
+}
+
+func parseBackoff(bc BackoffConfig) (supervisor.Backoff, error) {
+	var b supervisor.Backoff
+	if bc.Initial != "" {
+		d, err := time.ParseDuration(bc.Initial)
+		if err != nil {
+			return b, fmt.Errorf("invalid backoff.initial %q: %w", bc.Initial, err)
+		}
+		b.Initial = d
+	}
+	if bc.Max != "" {
+		d, err := time.ParseDuration(bc.Max)
+		if err != nil {
+			return b, fmt.Errorf("invalid backoff.max %q: %w", bc.Max, err)
+		}
+		b.Max = d
+	}
+	b.Multiplier = bc.Multiplier
+	return b, nil
+}
+
+// topoSort orders services so each appears after its DependsOn entries,
+// erroring out on an unknown dependency or a cycle.
+func topoSort(services []ServiceConfig) ([]ServiceConfig, error) {
+	byName := make(map[string]ServiceConfig, len(services))
+	for _, sc := range services {
+		byName[sc.Name] = sc
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(services))
+	ordered := make([]ServiceConfig, 0, len(services))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("config: dependency cycle involving %q", name)
+		}
+		sc, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("config: unknown dependency %q", name)
+		}
+		state[name] = visiting
+		for _, dep := range sc.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, sc)
+		return nil
+	}
+
+	for _, sc := range services {
+		if err := visit(sc.Name); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopoSortOrdersByDependency(t *testing.T) {
+	services := []ServiceConfig{
+		{Name: "ui", DependsOn: []string{"agent"}},
+		{Name: "agent"},
+	}
+	ordered, err := topoSort(services)
+	if err != nil {
+		t.Fatalf("topoSort: %v", err)
+	}
+	if len(ordered) != 2 || ordered[0].Name != "agent" || ordered[1].Name != "ui" {
+		t.Fatalf("got order %v, want [agent ui]", names(ordered))
+	}
+}
+
+func TestTopoSortDetectsCycle(t *testing.T) {
+	services := []ServiceConfig{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+	if _, err := topoSort(services); err == nil {
+		t.Fatal("expected a cycle error, got nil")
+	}
+}
+
+func TestTopoSortDetectsUnknownDependency(t *testing.T) {
+	services := []ServiceConfig{
+		{Name: "a", DependsOn: []string{"missing"}},
+	}
+	if _, err := topoSort(services); err == nil {
+		t.Fatal("expected an unknown-dependency error, got nil")
+	}
+}
+
+func names(services []ServiceConfig) []string {
+	out := make([]string, len(services))
+	for i, s := range services {
+		out[i] = s.Name
+	}
+	return out
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	cases := map[string]bool{
+		"":           true,
+		"never":      true,
+		"on-failure": true,
+		"onfailure":  true,
+		"always":     true,
+		"bogus":      false,
+	}
+	for in, wantOK := range cases {
+		_, err := parseRestartPolicy(in)
+		if (err == nil) != wantOK {
+			t.Errorf("parseRestartPolicy(%q) error = %v, want ok=%v", in, err, wantOK)
+		}
+	}
+}
+
+func TestParseBackoff(t *testing.T) {
+	b, err := parseBackoff(BackoffConfig{Initial: "1s", Max: "10s", Multiplier: 3})
+	if err != nil {
+		t.Fatalf("parseBackoff: %v", err)
+	}
+	if b.Initial != time.Second || b.Max != 10*time.Second || b.Multiplier != 3 {
+		t.Errorf("got %+v, want Initial=1s Max=10s Multiplier=3", b)
+	}
+}
+
+func TestParseBackoffRejectsInvalidDuration(t *testing.T) {
+	if _, err := parseBackoff(BackoffConfig{Initial: "not-a-duration"}); err == nil {
+		t.Fatal("expected an error for an invalid duration")
+	}
+}
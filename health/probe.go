@@ -0,0 +1,73 @@
+// Package health implements readiness probes services can use to signal that
+// they're actually ready for traffic, not just that their process has
+// started.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+)
+
+// Probe reports whether a service is ready. Check should return promptly;
+// callers are responsible for retrying and timing out.
+type Probe interface {
+	Check(ctx context.Context) error
+}
+
+// TCPProbe succeeds once a TCP connection to Address can be established.
+type TCPProbe struct {
+	Address string
+}
+
+func (p TCPProbe) Check(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return fmt.Errorf("health: tcp dial %s: %w", p.Address, err)
+	}
+	return conn.Close()
+}
+
+// HTTPProbe succeeds once an HTTP GET to URL returns ExpectStatus (defaults
+// to http.StatusOK when zero).
+type HTTPProbe struct {
+	URL          string
+	ExpectStatus int
+}
+
+func (p HTTPProbe) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return fmt.Errorf("health: building request for %s: %w", p.URL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("health: GET %s: %w", p.URL, err)
+	}
+	defer resp.Body.Close()
+
+	want := p.ExpectStatus
+	if want == 0 {
+		want = http.StatusOK
+	}
+	if resp.StatusCode != want {
+		return fmt.Errorf("health: GET %s: got status %d, want %d", p.URL, resp.StatusCode, want)
+	}
+	return nil
+}
+
+// CommandProbe succeeds once running Command (via "sh -c") exits 0.
+type CommandProbe struct {
+	Command string
+}
+
+func (p CommandProbe) Check(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("health: command %q: %w", p.Command, err)
+	}
+	return nil
+}
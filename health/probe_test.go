@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+
+	p := TCPProbe{Address: ln.Addr().String()}
+	if err := p.Check(context.Background()); err != nil {
+		t.Errorf("Check against a listening port: %v", err)
+	}
+
+	closed := TCPProbe{Address: "127.0.0.1:1"}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := closed.Check(ctx); err == nil {
+		t.Error("expected an error dialing a port nothing listens on")
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/teapot" {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := HTTPProbe{URL: srv.URL}
+	if err := p.Check(context.Background()); err != nil {
+		t.Errorf("Check against a 200 response: %v", err)
+	}
+
+	want := HTTPProbe{URL: srv.URL + "/teapot", ExpectStatus: http.StatusTeapot}
+	if err := want.Check(context.Background()); err != nil {
+		t.Errorf("Check with matching ExpectStatus: %v", err)
+	}
+
+	mismatch := HTTPProbe{URL: srv.URL + "/teapot"}
+	if err := mismatch.Check(context.Background()); err == nil {
+		t.Error("expected an error when the status doesn't match the default ExpectStatus (200)")
+	}
+}
+
+func TestCommandProbe(t *testing.T) {
+	ok := CommandProbe{Command: "exit 0"}
+	if err := ok.Check(context.Background()); err != nil {
+		t.Errorf("Check for a command that exits 0: %v", err)
+	}
+
+	fails := CommandProbe{Command: "exit 1"}
+	if err := fails.Check(context.Background()); err == nil {
+		t.Error("expected an error for a command that exits non-zero")
+	}
+}
@@ -0,0 +1,161 @@
+// Package logs captures a service's stdout/stderr, tags each line with the
+// service name and a timestamp, writes it to a rotating on-disk log and an
+// in-memory ring buffer, and lets HTTP clients tail or stream it.
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Options controls how a Capture persists and retains lines.
+type Options struct {
+	Dir        string // directory the rotating log file is written under
+	MaxBytes   int64  // rotate before a write that would take the current file past this size; 0 disables rotation
+	MaxBackups int    // number of rotated files to keep
+	RingSize   int    // number of recent lines kept in memory for Tail/Stream
+	Echo       io.Writer
+}
+
+// Capture tags and fans out one service's combined stdout/stderr.
+type Capture struct {
+	name string
+	echo io.Writer
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	mu   sync.Mutex
+	file *rotatingFile
+	ring []string
+	subs map[chan string]struct{}
+
+	done chan struct{}
+}
+
+// NewCapture creates a Capture for the named service. Its Writer() should be
+// used as the service's combined stdout/stderr.
+func NewCapture(name string, opts Options) (*Capture, error) {
+	var rf *rotatingFile
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("logs: creating %s: %w", opts.Dir, err)
+		}
+		f, err := newRotatingFile(filepath.Join(opts.Dir, name+".log"), opts.MaxBytes, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		rf = f
+	}
+
+	ringSize := opts.RingSize
+	if ringSize <= 0 {
+		ringSize = 1000
+	}
+
+	pr, pw := io.Pipe()
+	c := &Capture{
+		name: name,
+		echo: opts.Echo,
+		pr:   pr,
+		pw:   pw,
+		file: rf,
+		ring: make([]string, 0, ringSize),
+		subs: make(map[chan string]struct{}),
+		done: make(chan struct{}),
+	}
+	go c.consume(ringSize)
+	return c, nil
+}
+
+// Writer returns the io.Writer a service's Stdout/Stderr should be set to.
+func (c *Capture) Writer() io.Writer { return c.pw }
+
+func (c *Capture) consume(ringSize int) {
+	defer close(c.done)
+	scanner := bufio.NewScanner(c.pr)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := fmt.Sprintf("%s [%s] %s", time.Now().Format(time.RFC3339), c.name, scanner.Text())
+		c.append(line, ringSize)
+	}
+	// A non-EOF error (most commonly bufio.ErrTooLong, from a single line
+	// over the scanner's 1MB buffer) leaves c.pr with unread data and no
+	// reader left to drain it. Discard the rest so the writer side (the
+	// child process's stdout/stderr copy goroutine) never blocks on a pipe
+	// nobody is reading from instead of exiting cleanly.
+	if scanner.Err() != nil {
+		_, _ = io.Copy(io.Discard, c.pr)
+	}
+}
+
+func (c *Capture) append(line string, ringSize int) {
+	c.mu.Lock()
+	if c.file != nil {
+		_, _ = c.file.WriteString(line + "\n")
+	}
+	c.ring = append(c.ring, line)
+	if len(c.ring) > ringSize {
+		c.ring = c.ring[len(c.ring)-ringSize:]
+	}
+	subs := make([]chan string, 0, len(c.subs))
+	for ch := range c.subs {
+		subs = append(subs, ch)
+	}
+	c.mu.Unlock()
+
+	if c.echo != nil {
+		fmt.Fprintln(c.echo, line)
+	}
+	for _, ch := range subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// Tail returns up to the last n lines currently retained in memory.
+func (c *Capture) Tail(n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 || n > len(c.ring) {
+		n = len(c.ring)
+	}
+	out := make([]string, n)
+	copy(out, c.ring[len(c.ring)-n:])
+	return out
+}
+
+// Subscribe registers a channel that receives lines appended after the call.
+// The channel buffers 64 lines; a line arriving while it is full is dropped
+// for that subscriber. The returned func must be called to unsubscribe.
+func (c *Capture) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, 64)
+	c.mu.Lock()
+	c.subs[ch] = struct{}{}
+	c.mu.Unlock()
+	return ch, func() {
+		c.mu.Lock()
+		delete(c.subs, ch)
+		c.mu.Unlock()
+	}
+}
+
+// Close closes the capture's write end, letting the consumer goroutine drain
+// and exit, then closes the rotating log file.
+func (c *Capture) Close() error {
+	err := c.pw.Close()
+	<-c.done
+	if c.file != nil {
+		if ferr := c.file.Close(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
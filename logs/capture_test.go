@@ -0,0 +1,64 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureTailsWrittenLines(t *testing.T) {
+	c, err := NewCapture("svc", Options{RingSize: 10})
+	if err != nil {
+		t.Fatalf("NewCapture: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Writer().Write([]byte("one\ntwo\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && len(c.Tail(0)) < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	tail := c.Tail(0)
+	if len(tail) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(tail), tail)
+	}
+	if !strings.HasSuffix(tail[0], "[svc] one") || !strings.HasSuffix(tail[1], "[svc] two") {
+		t.Errorf("tail = %v, want lines tagged with service name in order", tail)
+	}
+}
+
+// TestCaptureOverLongLineDoesNotWedgeWriter is a regression test for the
+// scanner's 1MB line buffer overflowing: consume used to exit on the scan
+// error without draining c.pr, so any further write to Writer() (i.e. the
+// child's stdout/stderr) blocked forever on the unread pipe.
+func TestCaptureOverLongLineDoesNotWedgeWriter(t *testing.T) {
+	c, err := NewCapture("svc", Options{RingSize: 10})
+	if err != nil {
+		t.Fatalf("NewCapture: %v", err)
+	}
+	defer c.Close()
+
+	over := make([]byte, 2<<20) // exceeds the scanner's 1MB max buffer
+	for i := range over {
+		over[i] = 'x'
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := c.Writer().Write(over)
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("write blocked: consume did not drain the pipe after a scan error")
+	}
+}
@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+)
+
+// rotatingFile is an append-only file that rotates to path.1, path.2, ...
+// before a write that would take it past maxBytes, keeping at most
+// maxBackups old copies.
+type rotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	f    *os.File
+	size int64
+}
+
+func newRotatingFile(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("logs: opening %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("logs: stat %s: %w", path, err)
+	}
+	return &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups, f: f, size: info.Size()}, nil
+}
+
+func (r *rotatingFile) WriteString(s string) (int, error) {
+	if r.maxBytes > 0 && r.size+int64(len(s)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.WriteString(s)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+
+	if r.maxBackups <= 0 {
+		// Nothing to keep: truncate in place rather than letting the file
+		// grow without bound.
+		f, err := os.OpenFile(r.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("logs: truncating %s: %w", r.path, err)
+		}
+		r.f = f
+		r.size = 0
+		return nil
+	}
+
+	for i := r.maxBackups; i > 0; i-- {
+		src := r.backupPath(i - 1)
+		dst := r.backupPath(i)
+		if i-1 == 0 {
+			src = r.path
+		}
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if i == r.maxBackups {
+			os.Remove(dst)
+		}
+		os.Rename(src, dst)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("logs: reopening %s after rotation: %w", r.path, err)
+	}
+	r.f = f
+	r.size = 0
+	return nil
+}
+
+func (r *rotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+func (r *rotatingFile) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,94 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileRotatesAtMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "svc.log")
+
+	rf, err := newRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer rf.Close()
+
+	if _, err := rf.WriteString("0123456789"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	if _, err := rf.WriteString("next"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("expected %s.1 to exist after rotation: %v", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(data) != "next" {
+		t.Errorf("current file = %q, want %q", data, "next")
+	}
+}
+
+func TestRotatingFileKeepsOnlyMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "svc.log")
+
+	rf, err := newRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer rf.Close()
+
+	// After the first write fills the file to maxBytes, each further write
+	// would take it past maxBytes, forcing a rotation (three in all).
+	for _, s := range []string{"a", "b", "c", "d"} {
+		if _, err := rf.WriteString(s); err != nil {
+			t.Fatalf("WriteString(%q): %v", s, err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("expected no %s.3 to exist, maxBackups=2", path)
+	}
+	for _, n := range []int{1, 2} {
+		if _, err := os.Stat(fmt.Sprintf("%s.%d", path, n)); err != nil {
+			t.Errorf("expected %s.%d to exist: %v", path, n, err)
+		}
+	}
+}
+
+func TestRotatingFileTruncatesWhenNoBackupsKept(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "svc.log")
+
+	rf, err := newRotatingFile(path, 1, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer rf.Close()
+
+	if _, err := rf.WriteString("overflow"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	if _, err := rf.WriteString("next"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err == nil {
+		t.Error("expected no backup file when maxBackups=0")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(data) != "next" {
+		t.Errorf("current file = %q, want %q (old content should be truncated, not kept)", data, "next")
+	}
+}
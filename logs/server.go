@@ -0,0 +1,103 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Server exposes each service's Capture over HTTP so a log can be tailed or
+// streamed without attaching to the merged terminal output.
+type Server struct {
+	http *http.Server
+}
+
+// NewServer builds a server listening on addr (e.g. ":7799") serving:
+//
+//	GET /logs/{service}?tail=N   - last N lines (default 100) as text
+//	GET /logs/{service}/stream   - an SSE stream of new lines
+func NewServer(addr string, captures map[string]*Capture) *Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/logs/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/logs/")
+		rest = strings.TrimSuffix(rest, "/")
+		name, stream := rest, false
+		if idx := strings.LastIndex(rest, "/"); idx >= 0 {
+			name, stream = rest[:idx], rest[idx+1:] == "stream"
+		}
+
+		cap, ok := captures[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if stream {
+			serveStream(w, r, cap)
+			return
+		}
+		serveTail(w, r, cap)
+	})
+
+	return &Server{http: &http.Server{Addr: addr, Handler: mux}}
+}
+
+func serveTail(w http.ResponseWriter, r *http.Request, cap *Capture) {
+	n := 100
+	if raw := r.URL.Query().Get("tail"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			n = parsed
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range cap.Tail(n) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func serveStream(w http.ResponseWriter, r *http.Request, cap *Capture) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch, unsubscribe := cap.Subscribe()
+	defer unsubscribe()
+
+	for _, line := range cap.Tail(0) {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case line := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Start begins serving in the background. Errors after a graceful Stop are
+// not reported, matching http.Server.Shutdown's contract.
+func (s *Server) Start() {
+	go func() {
+		if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("logs: server error: %v\n", err)
+		}
+	}()
+}
+
+// Stop shuts the server down, waiting for in-flight requests (including
+// open SSE streams) to finish or ctx to be done.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
@@ -1,151 +1,120 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
-    "path/filepath"
-	"sync"
+	"path/filepath"
 	"syscall"
 	"time"
+
+	"intervention/config"
+	"intervention/logs"
+	"intervention/supervisor"
 )
 
 func main() {
-    homeDir, err := os.UserHomeDir()
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "failed to determine home directory: %v\n", err)
-        os.Exit(1)
-    }
-    baseDir := filepath.Join(homeDir, "Desktop", "intervention")
-    agentDir := filepath.Join(baseDir, "agent", "experiments")
-    uiDir := filepath.Join(baseDir, "ui")
-
-	// Start Agent (python run_robots.py) in its own process group
-	agentCmd := exec.Command("python3", "run_robots.py")
-    agentCmd.Dir = agentDir
-	agentCmd.Stdout = os.Stdout
-	agentCmd.Stderr = os.Stderr
-	agentCmd.Stdin = os.Stdin
-	agentCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	os.Exit(run())
+}
 
-	if err := agentCmd.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to start Agent: %v\n", err)
-		os.Exit(1)
+// run holds all of main's logic so that deferred cleanup (notably shutting
+// down the log HTTP server) executes before the process exits; main itself
+// only calls os.Exit, which would otherwise skip every defer.
+func run() int {
+	configPath := flag.String("config", "", "path to services.yaml/.json (default ~/.config/intervention/services.yaml)")
+	shutdownGrace := flag.Duration("shutdown-grace", 2*time.Second, "time to let each service drain before escalating to SIGKILL")
+	logDir := flag.String("log-dir", "", "directory for per-service rotating logs (default ~/.local/share/intervention/logs)")
+	logAddr := flag.String("log-addr", ":7799", "address the log tail/stream HTTP server listens on")
+	flag.Parse()
+
+	path := *configPath
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		path = defaultPath
 	}
-	agentPID := agentCmd.Process.Pid
-	fmt.Printf("Agent started (pid=%d)\n", agentPID)
-
-	// Start UI (yarn run start) in its own process group
-	uiCmd := exec.Command("yarn", "run", "start")
-    uiCmd.Dir = uiDir
-	uiCmd.Stdout = os.Stdout
-	uiCmd.Stderr = os.Stderr
-	uiCmd.Stdin = os.Stdin
-	uiCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	if err := uiCmd.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to start UI: %v\n", err)
-		_ = terminateProcessGroup(agentPID, syscall.SIGTERM)
-		time.Sleep(1 * time.Second)
-		_ = terminateProcessGroup(agentPID, syscall.SIGKILL)
-		os.Exit(1)
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config %s: %v\n", path, err)
+		return 1
 	}
-	uiPID := uiCmd.Process.Pid
-	fmt.Printf("UI started (pid=%d)\n", uiPID)
 
-	// Ensure cleanup on program exit
-	var cleanupOnce sync.Once
-	cleanup := func(exitOnReturn bool) {
-		cleanupOnce.Do(func() {
-			fmt.Println("Stopping services...")
-			_ = terminateProcessGroup(agentPID, syscall.SIGTERM)
-			_ = terminateProcessGroup(uiPID, syscall.SIGTERM)
-			time.Sleep(2 * time.Second)
-			_ = terminateProcessGroup(agentPID, syscall.SIGKILL)
-			_ = terminateProcessGroup(uiPID, syscall.SIGKILL)
-			if exitOnReturn {
-				// Give a moment for children to reap before exit
-				time.Sleep(200 * time.Millisecond)
-			}
-		})
+	services, err := cfg.ToServices()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
 	}
-	defer cleanup(false)
 
-	// Prepare waiters
-	agentDone := make(chan error, 1)
-	uiDone := make(chan error, 1)
-	go func() { agentDone <- agentCmd.Wait() }()
-	go func() { uiDone <- uiCmd.Wait() }()
-
-	// Listen for shutdown signals
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
-
-	// Exit when one process ends or a signal is received
-	exitCode := 0
-	var reason string
-	select {
-	case err := <-agentDone:
+	dir := *logDir
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			exitCode = extractExitCode(err)
+			fmt.Fprintf(os.Stderr, "failed to determine home directory: %v\n", err)
+			return 1
 		}
-		reason = "Agent exited"
-		cleanup(true)
-	case err := <-uiDone:
+		dir = filepath.Join(homeDir, ".local", "share", "intervention", "logs")
+	}
+
+	captures := make(map[string]*logs.Capture, len(services))
+	for _, svc := range services {
+		cap, err := logs.NewCapture(svc.Name, logs.Options{
+			Dir:        dir,
+			MaxBytes:   10 * 1024 * 1024,
+			MaxBackups: 5,
+			RingSize:   2000,
+			Echo:       os.Stdout,
+		})
 		if err != nil {
-			exitCode = extractExitCode(err)
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
 		}
-		reason = "UI exited"
-		cleanup(true)
-	case sig := <-sigCh:
-		reason = fmt.Sprintf("Received signal %v", sig)
-		if sig == os.Interrupt || sig == syscall.SIGINT {
-			exitCode = 130
-		} else {
-			exitCode = 1
-		}
-		cleanup(true)
+		captures[svc.Name] = cap
+		svc.Stdout = cap.Writer()
+		svc.Stderr = cap.Writer()
 	}
 
-	// Drain remaining waiters with a timeout
-	waitWithTimeout(agentDone, 5*time.Second)
-	waitWithTimeout(uiDone, 5*time.Second)
-
-	fmt.Printf("Exiting: %s (code=%d)\n", reason, exitCode)
-	os.Exit(exitCode)
-}
-
-func waitWithTimeout(ch <-chan error, timeout time.Duration) {
-	select {
-	case <-ch:
-		return
-	case <-time.After(timeout):
-		return
+	logServer := logs.NewServer(*logAddr, captures)
+	logServer.Start()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = logServer.Stop(ctx)
+	}()
+
+	sup := supervisor.New(services...)
+	if cfg.StopPeersOnCleanExit != nil {
+		sup.StopPeersOnCleanExit = *cfg.StopPeersOnCleanExit
 	}
-}
 
-func terminateProcessGroup(pid int, sig syscall.Signal) error {
-	if pid <= 0 {
-		return errors.New("invalid pid")
-	}
-	// Send to process group (negative pid). Fall back to direct PID.
-	if err := syscall.Kill(-pid, sig); err == nil {
-		return nil
+	if err := sup.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
 	}
-	return syscall.Kill(pid, sig)
-}
+	fmt.Printf("Services started, logs at http://localhost%s/logs/{service}\n", *logAddr)
 
-func extractExitCode(err error) int {
-	var exitErr *exec.ExitError
-	if errors.As(err, &exitErr) {
-		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-			return status.ExitStatus()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	go func() {
+		sig := <-sigCh
+		fmt.Printf("Received signal %v, forwarding to services...\n", sig)
+		forward, ok := sig.(syscall.Signal)
+		if !ok {
+			forward = syscall.SIGTERM
 		}
-	}
-	return 1
-}
-
+		sup.Shutdown(forward, *shutdownGrace)
+	}()
 
+	exitCode := sup.Run()
+	fmt.Printf("Exiting (code=%d)\n", exitCode)
 
+	for _, cap := range captures {
+		_ = cap.Close()
+	}
+	return exitCode
+}
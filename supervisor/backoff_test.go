@@ -0,0 +1,40 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffNext(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 8 * time.Second, Multiplier: 2}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{10, 8 * time.Second}, // capped at Max
+	}
+	for _, c := range cases {
+		if got := b.next(c.attempt); got != c.want {
+			t.Errorf("next(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestBackoffNextZeroInitialIsNoDelay(t *testing.T) {
+	var b Backoff
+	if got := b.next(5); got != 0 {
+		t.Errorf("next(5) = %v, want 0", got)
+	}
+}
+
+func TestBackoffNextDefaultsMultiplier(t *testing.T) {
+	b := Backoff{Initial: time.Second} // Multiplier <= 1 should fall back to 2
+	if got := b.next(2); got != 4*time.Second {
+		t.Errorf("next(2) = %v, want %v", got, 4*time.Second)
+	}
+}
@@ -0,0 +1,100 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ReadinessProbe reports whether a started Service is actually ready, e.g. a
+// port accepting connections or an HTTP endpoint returning 200. Implemented
+// by the health package's probe types.
+type ReadinessProbe interface {
+	Check(ctx context.Context) error
+}
+
+// RestartPolicy controls whether a Service is restarted after it exits.
+type RestartPolicy int
+
+const (
+	// Never means the service is not restarted regardless of exit status.
+	Never RestartPolicy = iota
+	// OnFailure restarts the service only when it exits with a non-zero
+	// status, or is killed by a signal.
+	OnFailure
+	// Always restarts the service no matter how it exits.
+	Always
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case Never:
+		return "never"
+	case OnFailure:
+		return "on-failure"
+	case Always:
+		return "always"
+	default:
+		return "unknown"
+	}
+}
+
+// Backoff describes the capped exponential backoff applied between restarts.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// next returns the delay to wait before the given restart attempt (0-indexed)
+// and caps it at Max.
+func (b Backoff) next(attempt int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	mult := b.Multiplier
+	if mult <= 1 {
+		mult = 2
+	}
+	d := b.Initial
+	for i := 0; i < attempt; i++ {
+		d = time.Duration(float64(d) * mult)
+		if b.Max > 0 && d >= b.Max {
+			return b.Max
+		}
+	}
+	return d
+}
+
+// Service describes one child process the supervisor manages.
+type Service struct {
+	// Name identifies the service in logs and in Supervisor lookups.
+	Name string
+	// Command is the executable to run; Args are passed as-is to exec.Command.
+	Command string
+	Args    []string
+	// Dir is the working directory the command is started in.
+	Dir string
+	// Env, when non-nil, replaces the inherited environment entirely
+	// (same convention as exec.Cmd.Env).
+	Env []string
+
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	RestartPolicy RestartPolicy
+	// MaxRestarts caps the number of restarts; 0 means unlimited.
+	MaxRestarts int
+	Backoff     Backoff
+
+	// ReadinessProbe, if set, gates Start(): the supervisor waits for it to
+	// succeed before starting the next service, and treats a probe that
+	// never succeeds within ReadinessTimeout as a failed start, applying
+	// RestartPolicy to it like any other abnormal exit.
+	ReadinessProbe   ReadinessProbe
+	ReadinessTimeout time.Duration
+	// ReadinessInterval controls how often the probe is retried; it
+	// defaults to 500ms when zero.
+	ReadinessInterval time.Duration
+}
@@ -0,0 +1,450 @@
+// Package supervisor models a set of long-running child processes
+// ("services"), starting them, reaping their exits via Wait4, and
+// restarting them according to a per-service RestartPolicy.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// proc is the runtime state the Supervisor tracks for one Service.
+type proc struct {
+	service    *Service
+	pid        int
+	restarts   int
+	stopped    bool // intentionally stopped; do not restart
+	finished   bool // exited and will not be restarted (stopped, policy, or exhausted)
+	probing    bool // waitReady owns restart decisions for this proc right now
+	generation int  // bumped every time startLocked starts a new process
+}
+
+// Supervisor owns the lifecycle of a fixed set of services.
+type Supervisor struct {
+	mu    sync.Mutex
+	procs []*proc
+
+	pollInterval time.Duration
+
+	// StopPeersOnCleanExit, when true, stops every other service once a
+	// service exits cleanly and is not restarted (its RestartPolicy or
+	// MaxRestarts rules out a restart). An abnormal exit that is not
+	// restarted does not stop the peers. Set it to false to let unrelated
+	// services keep running after a clean exit too.
+	StopPeersOnCleanExit bool
+}
+
+// New creates a Supervisor for the given services. Start launches them in
+// the order they're passed here, and Shutdown stops them in reverse order.
+func New(services ...*Service) *Supervisor {
+	procs := make([]*proc, 0, len(services))
+	for _, svc := range services {
+		procs = append(procs, &proc{service: svc})
+	}
+	return &Supervisor{procs: procs, pollInterval: 200 * time.Millisecond, StopPeersOnCleanExit: true}
+}
+
+// Start launches each service in order, waiting for any ReadinessProbe to
+// succeed before moving on to the next. A service whose probe never
+// succeeds within ReadinessTimeout is restarted according to its
+// RestartPolicy and re-probed; Start gives up and returns an error once that
+// service's restarts are exhausted.
+func (s *Supervisor) Start() error {
+	for _, p := range s.procs {
+		s.mu.Lock()
+		err := s.startLocked(p)
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("starting %s: %w", p.service.Name, err)
+		}
+
+		if p.service.ReadinessProbe == nil {
+			continue
+		}
+		if err := s.waitReady(p); err != nil {
+			return fmt.Errorf("starting %s: %w", p.service.Name, err)
+		}
+	}
+	return nil
+}
+
+// waitReady blocks until p's readiness probe succeeds, restarting p (per its
+// RestartPolicy) and retrying each time the probe fails to succeed within
+// ReadinessTimeout, until restarts are exhausted.
+//
+// It defers to any concurrent Stop or Restart call for p: a Stop is honored
+// by giving up immediately rather than restarting over it, and p.generation
+// (bumped by every startLocked) lets waitReady notice when a Restart has
+// already replaced the process it was probing, so it bails instead of
+// killing and re-starting the replacement behind Restart's back.
+func (s *Supervisor) waitReady(p *proc) error {
+	s.mu.Lock()
+	p.probing = true
+	myGen := p.generation
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		p.probing = false
+		s.mu.Unlock()
+	}()
+
+	for {
+		timeout := p.service.ReadinessTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		interval := p.service.ReadinessInterval
+		if interval <= 0 {
+			interval = 500 * time.Millisecond
+		}
+
+		deadline := time.Now().Add(timeout)
+		var lastErr error
+		for time.Now().Before(deadline) {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			lastErr = p.service.ReadinessProbe.Check(ctx)
+			cancel()
+			if lastErr == nil {
+				return nil
+			}
+			time.Sleep(interval)
+		}
+
+		// The probe never succeeded within the timeout. Check under the
+		// same lock whether this service was stopped or already replaced
+		// by a concurrent Restart before acting on our stale pid.
+		s.mu.Lock()
+		if p.stopped {
+			s.mu.Unlock()
+			return nil
+		}
+		if p.generation != myGen {
+			// Restart already swapped in a new process; it owns the
+			// service now, so don't kill or replace it.
+			s.mu.Unlock()
+			return nil
+		}
+		restarts := p.restarts
+		pid := p.pid
+		if pid > 0 {
+			_ = terminateProcessGroup(pid, syscall.SIGKILL)
+		}
+		s.mu.Unlock()
+		if pid > 0 {
+			s.reapOnce()
+		}
+
+		delay := p.service.Backoff.next(restarts)
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+
+		s.mu.Lock()
+		if p.stopped {
+			s.mu.Unlock()
+			return nil
+		}
+		if p.generation != myGen {
+			s.mu.Unlock()
+			return nil
+		}
+		if p.service.RestartPolicy == Never ||
+			(p.service.MaxRestarts > 0 && restarts >= p.service.MaxRestarts) {
+			s.mu.Unlock()
+			return fmt.Errorf("readiness probe never succeeded: %w", lastErr)
+		}
+		p.restarts = restarts + 1
+		err := s.startLocked(p)
+		myGen = p.generation
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("restarting after failed readiness probe: %w", err)
+		}
+	}
+}
+
+func (s *Supervisor) startLocked(p *proc) error {
+	svc := p.service
+	cmd := exec.Command(svc.Command, svc.Args...)
+	cmd.Dir = svc.Dir
+	cmd.Env = svc.Env
+	cmd.Stdout = svc.Stdout
+	cmd.Stderr = svc.Stderr
+	cmd.Stdin = svc.Stdin
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.pid = cmd.Process.Pid
+	p.stopped = false
+	p.finished = false
+	p.generation++
+	return nil
+}
+
+// Restart stops (if running) and restarts the named service, resetting its
+// restart counter.
+func (s *Supervisor) Restart(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.findLocked(name)
+	if p == nil {
+		return fmt.Errorf("supervisor: no such service %q", name)
+	}
+	if p.pid > 0 {
+		_ = terminateProcessGroup(p.pid, syscall.SIGKILL)
+	}
+	p.restarts = 0
+	return s.startLocked(p)
+}
+
+// Stop sends sig to the named service's process group and marks it as
+// intentionally stopped so the reap loop will not restart it.
+func (s *Supervisor) Stop(name string, sig syscall.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.findLocked(name)
+	if p == nil {
+		return fmt.Errorf("supervisor: no such service %q", name)
+	}
+	p.stopped = true
+	if p.pid <= 0 {
+		return nil
+	}
+	return terminateProcessGroup(p.pid, sig)
+}
+
+// StopAll shuts every service down in reverse start order using SIGTERM,
+// giving each one up to grace to drain before moving to the next.
+func (s *Supervisor) StopAll(grace time.Duration) {
+	s.Shutdown(syscall.SIGTERM, grace)
+}
+
+// Shutdown stops services in reverse start order: the last-started service
+// (typically the most dependent, e.g. the UI) is signaled and drained first,
+// then the next, down to the first-started. Each service is sent sig and
+// given up to grace to exit on its own; if it's still alive when grace
+// elapses it is escalated to SIGKILL before moving on to the next service.
+func (s *Supervisor) Shutdown(sig syscall.Signal, grace time.Duration) {
+	s.mu.Lock()
+	order := make([]*proc, len(s.procs))
+	copy(order, s.procs)
+	s.mu.Unlock()
+
+	for i := len(order) - 1; i >= 0; i-- {
+		p := order[i]
+
+		s.mu.Lock()
+		p.stopped = true
+		pid := p.pid
+		s.mu.Unlock()
+		if pid <= 0 {
+			continue
+		}
+
+		_ = terminateProcessGroup(pid, sig)
+
+		deadline := time.Now().Add(grace)
+		for time.Now().Before(deadline) && s.pidOf(p) > 0 {
+			time.Sleep(s.pollInterval)
+			s.reapOnce()
+		}
+
+		if alive := s.pidOf(p); alive > 0 {
+			_ = terminateProcessGroup(alive, syscall.SIGKILL)
+			deadline = time.Now().Add(grace)
+			for time.Now().Before(deadline) && s.pidOf(p) > 0 {
+				time.Sleep(s.pollInterval)
+				s.reapOnce()
+			}
+		}
+	}
+}
+
+func (s *Supervisor) pidOf(p *proc) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.pid
+}
+
+func (s *Supervisor) findLocked(name string) *proc {
+	for _, p := range s.procs {
+		if p.service.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Run reaps exited children, classifies each exit, and applies the
+// service's RestartPolicy with capped exponential backoff until every
+// service has stopped for good. It returns the exit code to report for the
+// overall process: 0 if every service that stopped did so cleanly, 1
+// otherwise.
+//
+// Reaping is driven by SIGCHLD: a dedicated handler wakes the loop whenever
+// any child (including an orphaned grandchild reparented to us by yarn or
+// python3) changes state, and each wake-up drains every pending exit with
+// Wait4(-1, &ws, WNOHANG, nil) so nothing is left as a zombie. The poll
+// interval remains as a fallback in case a SIGCHLD is coalesced or missed.
+func (s *Supervisor) Run() int {
+	sigChld := make(chan os.Signal, 1)
+	signal.Notify(sigChld, syscall.SIGCHLD)
+	defer signal.Stop(sigChld)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	exitCode := 0
+	for {
+		if s.reapOnce() {
+			// reapOnce reported a non-zero exit; remember it but keep
+			// reaping so a crashing peer doesn't mask others.
+			exitCode = 1
+		}
+		if s.allStoppedForGood() {
+			return exitCode
+		}
+		select {
+		case <-sigChld:
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapOnce drains every currently-exited child (tracked services and any
+// orphaned grandchild alike) with a Wait4(WNOHANG) loop and returns true if
+// any tracked service exited abnormally (non-zero exit, signaled, or
+// core-dumped).
+func (s *Supervisor) reapOnce() bool {
+	abnormal := false
+	for {
+		var ws syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if err != nil || pid <= 0 {
+			return abnormal
+		}
+
+		s.mu.Lock()
+		p := s.findByPidLocked(pid)
+		if p == nil {
+			s.mu.Unlock()
+			continue
+		}
+		p.pid = 0
+		wasStopped := p.stopped
+		probing := p.probing
+		restarts := p.restarts
+		myGen := p.generation
+		s.mu.Unlock()
+
+		clean := ws.Exited() && ws.ExitStatus() == 0 && !ws.Signaled() && !ws.CoreDump()
+		if !clean {
+			abnormal = true
+		}
+
+		if wasStopped {
+			s.mu.Lock()
+			p.finished = true
+			s.mu.Unlock()
+			continue
+		}
+
+		if probing {
+			// waitReady is already polling this service's readiness and
+			// owns every restart decision for it; restarting here too
+			// would start a second, untracked process.
+			continue
+		}
+
+		restart := true
+		switch p.service.RestartPolicy {
+		case Always:
+		case OnFailure:
+			restart = !clean
+		case Never:
+			restart = false
+		}
+		if restart && p.service.MaxRestarts > 0 && restarts >= p.service.MaxRestarts {
+			restart = false
+		}
+
+		if !restart {
+			s.mu.Lock()
+			p.finished = true
+			s.mu.Unlock()
+			if clean && s.StopPeersOnCleanExit {
+				go s.StopAll(5 * time.Second)
+			}
+			continue
+		}
+
+		delay := p.service.Backoff.next(restarts)
+		s.scheduleRestart(p, myGen, restarts, delay)
+	}
+}
+
+// scheduleRestart waits out a restart's backoff delay off the shared reap
+// loop (so one service's backoff never stalls reaping of the rest, see
+// reapOnce) and then restarts p, unless a concurrent Stop or Restart has
+// since claimed it: p.stopped is checked to honor an intentional stop, and
+// p.generation is compared against myGen (p.generation as of the exit that
+// triggered this restart) so a Restart that already replaced the process
+// during the delay is not overridden, exactly as waitReady defers to both.
+func (s *Supervisor) scheduleRestart(p *proc, myGen, restarts int, delay time.Duration) {
+	restart := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if p.stopped {
+			p.finished = true
+			return
+		}
+		if p.generation != myGen {
+			return
+		}
+		p.restarts = restarts + 1
+		_ = s.startLocked(p)
+	}
+	if delay <= 0 {
+		restart()
+		return
+	}
+	time.AfterFunc(delay, restart)
+}
+
+func (s *Supervisor) findByPidLocked(pid int) *proc {
+	for _, p := range s.procs {
+		if p.pid == pid {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) allStoppedForGood() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.procs {
+		if p.pid > 0 || !p.finished {
+			return false
+		}
+	}
+	return true
+}
+
+func terminateProcessGroup(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return fmt.Errorf("supervisor: invalid pid %d", pid)
+	}
+	if err := syscall.Kill(-pid, sig); err == nil {
+		return nil
+	}
+	return syscall.Kill(pid, sig)
+}
@@ -0,0 +1,313 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// neverReadyProbe always fails, simulating a service that never opens its
+// port.
+type neverReadyProbe struct{}
+
+func (neverReadyProbe) Check(ctx context.Context) error {
+	return errors.New("never ready")
+}
+
+func waitForPid0(t *testing.T, s *Supervisor, p *proc, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		s.reapOnce()
+		if s.pidOf(p) == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("service did not exit within %s", timeout)
+}
+
+func TestReapOnceFinishesNeverPolicyService(t *testing.T) {
+	svc := &Service{Name: "ok", Command: "/bin/sh", Args: []string{"-c", "exit 0"}, RestartPolicy: Never}
+	s := New(svc)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	p := s.procs[0]
+	waitForPid0(t, s, p, 2*time.Second)
+
+	if !p.finished {
+		t.Error("expected finished=true for a Never-policy service after exit")
+	}
+}
+
+func TestReapOnceRestartsOnFailureUntilMaxRestarts(t *testing.T) {
+	svc := &Service{
+		Name:          "fails",
+		Command:       "/bin/sh",
+		Args:          []string{"-c", "exit 1"},
+		RestartPolicy: OnFailure,
+		MaxRestarts:   1,
+	}
+	s := New(svc)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	p := s.procs[0]
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && !p.finished {
+		s.reapOnce()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !p.finished {
+		t.Fatal("expected service to eventually be marked finished")
+	}
+	if p.restarts != 1 {
+		t.Errorf("restarts = %d, want 1 (MaxRestarts)", p.restarts)
+	}
+}
+
+// TestWaitReadyDoesNotDoubleRestart is a regression test for a race where
+// waitReady's own restart-on-timeout logic and reapOnce's restart-on-exit
+// logic could both decide to restart the same failed-readiness service,
+// leaking an extra, untracked process. Each process invocation appends a
+// line to a counter file; if the race were present, the reaped-but-replaced
+// process from reapOnce would leave an extra untracked pid and Start would
+// not account for it. With the fix, exactly one process per attempt is ever
+// started: the initial start plus one restart before MaxRestarts is hit.
+func TestWaitReadyDoesNotDoubleRestart(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+
+	svc := &Service{
+		Name:              "never-ready",
+		Command:           "/bin/sh",
+		Args:              []string{"-c", "echo run >> " + counter},
+		RestartPolicy:     OnFailure,
+		MaxRestarts:       1,
+		ReadinessProbe:    neverReadyProbe{},
+		ReadinessTimeout:  50 * time.Millisecond,
+		ReadinessInterval: 10 * time.Millisecond,
+	}
+	s := New(svc)
+
+	err := s.Start()
+	if err == nil {
+		t.Fatal("expected Start to fail once readiness restarts are exhausted")
+	}
+
+	// Let any in-flight exit actually land before inspecting state.
+	time.Sleep(100 * time.Millisecond)
+	s.reapOnce()
+
+	p := s.procs[0]
+	if p.restarts != 1 {
+		t.Errorf("restarts = %d, want 1 (one restart before MaxRestarts)", p.restarts)
+	}
+	if pid := s.pidOf(p); pid != 0 {
+		t.Errorf("pid = %d, want 0 (no process should still be tracked as running)", pid)
+	}
+
+	data, readErr := os.ReadFile(counter)
+	if readErr != nil {
+		t.Fatalf("reading counter file: %v", readErr)
+	}
+	runs := 0
+	for _, b := range data {
+		if b == '\n' {
+			runs++
+		}
+	}
+	if runs != 2 {
+		t.Errorf("process ran %d times, want exactly 2 (initial start + 1 restart)", runs)
+	}
+}
+
+// TestWaitReadyHonorsStop is a regression test for waitReady ignoring a
+// concurrent Stop call: without the p.stopped check, waitReady would restart
+// the service anyway once its readiness probe timed out, overriding the
+// stop request.
+func TestWaitReadyHonorsStop(t *testing.T) {
+	svc := &Service{
+		Name:              "stop-me",
+		Command:           "/bin/sh",
+		Args:              []string{"-c", "sleep 2"},
+		RestartPolicy:     Always,
+		ReadinessProbe:    neverReadyProbe{},
+		ReadinessTimeout:  100 * time.Millisecond,
+		ReadinessInterval: 10 * time.Millisecond,
+	}
+	s := New(svc)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start() }()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := s.Stop("stop-me", syscall.SIGTERM); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+
+	p := s.procs[0]
+	if p.restarts != 0 {
+		t.Errorf("restarts = %d, want 0 (Stop should have prevented a restart)", p.restarts)
+	}
+}
+
+// TestRestartDuringWaitReadyIsNotOverridden is a regression test for a race
+// where waitReady's own restart-on-timeout logic could kill and replace a
+// process that a concurrent Restart call had already swapped in, based on a
+// stale pid/restarts snapshot. Each process invocation appends a line to a
+// counter file; without the generation check, the process Restart started
+// would be killed and replaced yet again by waitReady once its timeout
+// fired, leaving three lines instead of two.
+func TestRestartDuringWaitReadyIsNotOverridden(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+
+	svc := &Service{
+		Name:              "restart-race",
+		Command:           "/bin/sh",
+		Args:              []string{"-c", "echo run >> " + counter + " && sleep 1"},
+		RestartPolicy:     Always,
+		ReadinessProbe:    neverReadyProbe{},
+		ReadinessTimeout:  200 * time.Millisecond,
+		ReadinessInterval: 10 * time.Millisecond,
+	}
+	s := New(svc)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start() }()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := s.Restart("restart-race"); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Restart")
+	}
+
+	data, readErr := os.ReadFile(counter)
+	if readErr != nil {
+		t.Fatalf("reading counter file: %v", readErr)
+	}
+	runs := 0
+	for _, b := range data {
+		if b == '\n' {
+			runs++
+		}
+	}
+	if runs != 2 {
+		t.Errorf("process ran %d times, want exactly 2 (initial start + the Restart call)", runs)
+	}
+}
+
+// TestReapOnceHonorsStopDuringBackoff is a regression test for reapOnce
+// ignoring a concurrent Stop call during a restart's backoff delay: without
+// rechecking p.stopped once the delay elapses, the scheduled restart would
+// fire anyway and override the stop request, exactly the bug
+// TestWaitReadyHonorsStop covers for waitReady's own restart path.
+func TestReapOnceHonorsStopDuringBackoff(t *testing.T) {
+	svc := &Service{
+		Name:          "stop-during-backoff",
+		Command:       "/bin/sh",
+		Args:          []string{"-c", "exit 1"},
+		RestartPolicy: Always,
+		Backoff:       Backoff{Initial: 200 * time.Millisecond},
+	}
+	s := New(svc)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	p := s.procs[0]
+	waitForPid0(t, s, p, 2*time.Second)
+
+	if err := s.Stop("stop-during-backoff", syscall.SIGTERM); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// Outlast the scheduled restart's backoff delay.
+	time.Sleep(400 * time.Millisecond)
+
+	if pid := s.pidOf(p); pid != 0 {
+		t.Errorf("pid = %d, want 0 (Stop during backoff should have prevented the scheduled restart)", pid)
+	}
+	if !p.finished {
+		t.Error("expected finished=true (Stop should settle the service, not leave a restart pending)")
+	}
+	if p.restarts != 0 {
+		t.Errorf("restarts = %d, want 0 (Stop should have prevented a restart)", p.restarts)
+	}
+}
+
+// TestRestartDuringReapOnceBackoffIsNotOverridden is a regression test for a
+// race where reapOnce's own backoff-then-restart logic could fire after a
+// concurrent Restart call had already swapped in a new process, based on a
+// stale generation snapshot. The command exits once (to drive the service
+// into its backoff delay), appending a line to a counter file each run; a
+// manual Restart during that delay swaps in a second process that sleeps
+// instead of exiting. Without the generation check, the original scheduled
+// restart would fire once the delay elapsed and start another process over
+// that one, leaving a third line and a leaked, untracked process.
+func TestRestartDuringReapOnceBackoffIsNotOverridden(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+
+	svc := &Service{
+		Name:    "reap-restart-race",
+		Command: "/bin/sh",
+		Args: []string{"-c",
+			"echo run >> " + counter + "; n=$(wc -l < " + counter + "); " +
+				"if [ \"$n\" -lt 2 ]; then exit 1; else sleep 1; fi"},
+		RestartPolicy: Always,
+		Backoff:       Backoff{Initial: 200 * time.Millisecond},
+	}
+	s := New(svc)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	p := s.procs[0]
+	waitForPid0(t, s, p, 2*time.Second)
+
+	if err := s.Restart("reap-restart-race"); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+
+	// Outlast the original exit's scheduled restart; without the fix it
+	// fires here and replaces the process Restart just started.
+	time.Sleep(400 * time.Millisecond)
+
+	data, readErr := os.ReadFile(counter)
+	if readErr != nil {
+		t.Fatalf("reading counter file: %v", readErr)
+	}
+	runs := 0
+	for _, b := range data {
+		if b == '\n' {
+			runs++
+		}
+	}
+	if runs != 2 {
+		t.Errorf("process ran %d times, want exactly 2 (initial exit + the Restart call)", runs)
+	}
+}